@@ -0,0 +1,206 @@
+// Copyright 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+// This file contains the build backends used by the exec reflect path
+// (see reflect.go) to turn a generated prog.go into a runnable binary,
+// independent of whether the target project uses go modules, a plain
+// GOPATH, gb, or a wrapper build system such as Bazel or Pants.
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Builder compiles the reflection program written to tmpDir and returns
+// the path to the resulting binary.
+type Builder interface {
+	Build(tmpDir string) (binPath string, err error)
+}
+
+// detectBuilder chooses the Builder appropriate for the project that
+// contains wd, the directory the reflection program is generated into.
+// GOBUILD always wins, so CI setups using Bazel/Pants wrappers can force
+// their own build command regardless of what else is present on disk.
+func detectBuilder(wd string) (Builder, error) {
+	if gb := os.Getenv("GOBUILD"); gb != "" {
+		return &gobuildBuilder{cmd: gb}, nil
+	}
+
+	dir, err := filepath.Abs(wd)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if fileExists(filepath.Join(dir, "go.mod")) {
+			return &goModulesBuilder{moduleDir: dir}, nil
+		}
+		if dirExists(filepath.Join(dir, "src")) && dirExists(filepath.Join(dir, "vendor")) {
+			return &gbBuilder{}, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	if gopath := os.Getenv("GOPATH"); gopath != "" {
+		return &gopathBuilder{}, nil
+	}
+
+	return nil, fmt.Errorf("could not detect a build system for %v (no go.mod, gb project, or GOPATH found, and GOBUILD is unset)", wd)
+}
+
+func fileExists(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && !fi.IsDir()
+}
+
+func dirExists(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
+
+// goModulesBuilder builds the reflection program with `go build`, run
+// from inside the target module so that module resolution (including any
+// replace directives) picks up the target import path correctly.
+type goModulesBuilder struct {
+	moduleDir string
+	overlay   string // path to a go build -overlay JSON file, or ""
+}
+
+// SupportOverlay implements overlaySupporter.
+func (b *goModulesBuilder) SupportOverlay(path string) { b.overlay = path }
+
+func (b *goModulesBuilder) Build(tmpDir string) (string, error) {
+	binPath := filepath.Join(tmpDir, "prog")
+	args := []string{"build", "-o", binPath}
+	if *buildFlags != "" {
+		args = append(args, strings.Fields(*buildFlags)...)
+	}
+	if b.overlay != "" {
+		args = append(args, "-overlay", b.overlay)
+	}
+	rel, err := filepath.Rel(b.moduleDir, tmpDir)
+	if err != nil {
+		return "", err
+	}
+	args = append(args, "./"+filepath.ToSlash(rel))
+
+	cmd := exec.Command("go", args...)
+	cmd.Dir = b.moduleDir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("go build: %v", err)
+	}
+	return binPath, nil
+}
+
+// gopathBuilder builds the reflection program the classic, module-less
+// way: `go build` run with GO111MODULE=off so the import path is resolved
+// against GOPATH/src.
+type gopathBuilder struct{}
+
+func (b *gopathBuilder) Build(tmpDir string) (string, error) {
+	binPath := filepath.Join(tmpDir, "prog")
+	args := []string{"build", "-o", binPath}
+	if *buildFlags != "" {
+		args = append(args, strings.Fields(*buildFlags)...)
+	}
+	args = append(args, ".")
+
+	cmd := exec.Command("go", args...)
+	cmd.Dir = tmpDir
+	cmd.Env = append(os.Environ(), "GO111MODULE=off")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("go build: %v", err)
+	}
+	return binPath, nil
+}
+
+// gbBuilder is the original build path: shell out to the gb tool. It is
+// kept for projects that have not moved to go modules yet.
+type gbBuilder struct{}
+
+func (b *gbBuilder) Build(tmpDir string) (string, error) {
+	gbProjectDir, err := getGbInfo("GB_PROJECT_DIR")
+	if err != nil {
+		return "", err
+	}
+	gbBinSuffix, err := getGbInfo("GB_BIN_SUFFIX")
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command("gb", "build", tmpDir)
+	cmd.Dir = "."
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(gbProjectDir, "bin", filepath.Base(tmpDir)+gbBinSuffix), nil
+}
+
+func getGbInfo(param string) (string, error) {
+	cmd := exec.Command("gb", "info", param)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	return strings.Trim(stdout.String(), "\n"), nil
+}
+
+// gobuildBuilder shells out to an arbitrary build command, used to let
+// wrapper build systems (Bazel, Pants, ...) supply their own logic via
+// the GOBUILD environment variable. The command is invoked as:
+//
+//	$GOBUILD <tmpDir>
+//
+// and is expected to behave like `gb build`: leave a binary named after
+// tmpDir's base name in a location it prints to stdout as its final line.
+type gobuildBuilder struct {
+	cmd string
+}
+
+func (b *gobuildBuilder) Build(tmpDir string) (string, error) {
+	cmd := exec.Command(b.cmd, tmpDir)
+	cmd.Dir = "."
+	var stdout strings.Builder
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %v", b.cmd, err)
+	}
+
+	lines := strings.Split(strings.TrimRight(stdout.String(), "\n"), "\n")
+	binPath := strings.TrimSpace(lines[len(lines)-1])
+	if binPath == "" {
+		return "", fmt.Errorf("%s did not print a binary path", b.cmd)
+	}
+	return binPath, nil
+}
@@ -0,0 +1,149 @@
+// Copyright 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectBuilder_GobuildEnvWins(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module fixture\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOBUILD", "my-build-wrapper")
+
+	b, err := detectBuilder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gb, ok := b.(*gobuildBuilder)
+	if !ok {
+		t.Fatalf("got builder %T, want *gobuildBuilder", b)
+	}
+	if gb.cmd != "my-build-wrapper" {
+		t.Fatalf("got cmd %q, want my-build-wrapper", gb.cmd)
+	}
+}
+
+func TestDetectBuilder_GoModules(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module fixture\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(dir, "nested", "pkg")
+	if err := os.MkdirAll(sub, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := detectBuilder(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mb, ok := b.(*goModulesBuilder)
+	if !ok {
+		t.Fatalf("got builder %T, want *goModulesBuilder", b)
+	}
+	if mb.moduleDir != dir {
+		t.Fatalf("got moduleDir %q, want %q", mb.moduleDir, dir)
+	}
+}
+
+func TestDetectBuilder_Gb(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "src"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "vendor"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := detectBuilder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := b.(*gbBuilder); !ok {
+		t.Fatalf("got builder %T, want *gbBuilder", b)
+	}
+}
+
+func TestDetectBuilder_Gopath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GOPATH", dir)
+
+	b, err := detectBuilder(filepath.Join(dir, "src", "example.com", "fixture"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := b.(*gopathBuilder); !ok {
+		t.Fatalf("got builder %T, want *gopathBuilder", b)
+	}
+}
+
+func TestDetectBuilder_NoneFound(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GOPATH", "")
+
+	if _, err := detectBuilder(dir); err == nil {
+		t.Fatal("expected an error when no build system can be detected")
+	}
+}
+
+// TestGoModulesBuilder_Build exercises Build end to end, not just which
+// Builder detectBuilder selects: it writes a real go.mod and prog.go and
+// asserts a runnable binary comes out. moduleDir and tmpDir are both
+// absolute, matching what reflectExec now passes in (see reflect.go);
+// goModulesBuilder.Build computes tmpDir relative to moduleDir, which
+// filepath.Rel cannot do when only one of the two is absolute.
+func TestGoModulesBuilder_Build(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not available on PATH")
+	}
+
+	moduleDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(moduleDir, "go.mod"), []byte("module fixture\n\ngo 1.18\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tmpDir := filepath.Join(moduleDir, "gomock_reflect_fixture")
+	if err := os.Mkdir(tmpDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	const prog = `package main
+
+func main() {}
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "prog.go"), []byte(prog), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := detectBuilder(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := b.(*goModulesBuilder); !ok {
+		t.Fatalf("got builder %T, want *goModulesBuilder", b)
+	}
+
+	binPath, err := b.Build(tmpDir)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if fi, err := os.Stat(binPath); err != nil || fi.IsDir() {
+		t.Fatalf("Build did not produce a runnable binary at %v: %v", binPath, err)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gccgoimport reads the export data gccgo and gollvm embed in
+// compiled packages, so mockgen can reflect on interfaces without a gc
+// toolchain available. It mirrors the approach (and file layout) of the
+// standard library's internal go/internal/gccgoimporter package, trimmed
+// to the subset mockgen needs: resolving named interface types and their
+// method sets.
+package gccgoimport
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// pkgdefMember is the name ar gives the member holding a gccgo object's
+// export data, whether found loose (a .gox file) or inside a .a archive.
+const pkgdefMember = "__.PKGDEF"
+
+// Entry is one member of a Unix ar archive.
+type Entry struct {
+	Name string
+	Data []byte
+}
+
+// ReadArchive parses a Unix ar archive (the "!<arch>\n" format `ar`,
+// and so `go build -gccgoflags`, produces) into its member entries.
+func ReadArchive(r io.Reader) ([]Entry, error) {
+	magic := make([]byte, 8)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, fmt.Errorf("reading ar magic: %v", err)
+	}
+	if string(magic) != "!<arch>\n" {
+		return nil, fmt.Errorf("not a Unix ar archive")
+	}
+
+	var entries []Entry
+	hdr := make([]byte, 60)
+	for {
+		if _, err := io.ReadFull(r, hdr); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			return nil, fmt.Errorf("reading ar header: %v", err)
+		}
+		if string(hdr[58:60]) != "`\n" {
+			return nil, fmt.Errorf("malformed ar header (bad magic bytes)")
+		}
+		name := strings.TrimRight(string(hdr[0:16]), " ")
+		size, err := strconv.Atoi(strings.TrimSpace(string(hdr[48:58])))
+		if err != nil {
+			return nil, fmt.Errorf("malformed ar header size: %v", err)
+		}
+
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, fmt.Errorf("reading ar member %v: %v", name, err)
+		}
+		if size%2 == 1 {
+			// Members are padded to an even number of bytes.
+			if _, err := io.CopyN(io.Discard, r, 1); err != nil && err != io.EOF {
+				return nil, err
+			}
+		}
+		entries = append(entries, Entry{Name: name, Data: data})
+	}
+	return entries, nil
+}
+
+// FindPKGDEF returns the __.PKGDEF member of an archive read by
+// ReadArchive. A standalone .gox export-data file has no archive
+// framing at all; callers should try FindPKGDEF first and fall back to
+// treating the raw file contents as export data directly.
+func FindPKGDEF(entries []Entry) ([]byte, error) {
+	for _, e := range entries {
+		if strings.TrimSuffix(e.Name, "/") == pkgdefMember {
+			return e.Data, nil
+		}
+	}
+	return nil, fmt.Errorf("archive has no %s member", pkgdefMember)
+}
@@ -0,0 +1,65 @@
+// Copyright 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gccgoimport
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// buildArchive assembles a minimal Unix ar archive containing the given
+// named members, good enough to exercise ReadArchive/FindPKGDEF without
+// needing an actual ar binary or gccgo toolchain on the test machine.
+func buildArchive(members map[string]string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("!<arch>\n")
+	for name, data := range members {
+		fmt.Fprintf(&buf, "%-16s%-12s%-6s%-6s%-8s%-10d`\n", name, "0", "0", "0", "0", len(data))
+		buf.WriteString(data)
+		if len(data)%2 == 1 {
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestReadArchiveFindsPKGDEF(t *testing.T) {
+	archive := buildArchive(map[string]string{
+		"__.PKGDEF/": fixture,
+	})
+
+	entries, err := ReadArchive(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := FindPKGDEF(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != fixture {
+		t.Fatalf("got %q, want the fixture's PKGDEF contents", data)
+	}
+}
+
+func TestFindPKGDEFMissing(t *testing.T) {
+	entries, err := ReadArchive(bytes.NewReader(buildArchive(map[string]string{"other/": "x"})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FindPKGDEF(entries); err == nil {
+		t.Fatal("expected an error when no __.PKGDEF member is present")
+	}
+}
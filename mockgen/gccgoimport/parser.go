@@ -0,0 +1,366 @@
+// Copyright 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gccgoimport
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+// Version is the gccgo export data format version a PKGDEF was written
+// with. mockgen only needs to parse the declarations, so differences
+// between v1, v2 and v3 (mostly additions to the escape-analysis and
+// inlining annotations) don't otherwise affect parsing here.
+type Version string
+
+const (
+	V1 Version = "v1"
+	V2 Version = "v2"
+	V3 Version = "v3"
+)
+
+// SplitVersion detects the version header gccgo prepends to its export
+// data and returns the declarations that follow it.
+func SplitVersion(data []byte) (Version, []byte, error) {
+	for _, v := range []Version{V3, V2, V1} {
+		prefix := []byte(string(v) + ";")
+		if bytes.HasPrefix(data, prefix) {
+			return v, data[len(prefix):], nil
+		}
+	}
+	return "", nil, fmt.Errorf("unrecognized gccgo export data header (want v1;, v2; or v3;)")
+}
+
+// Parse reads gccgo export data (the payload SplitVersion returned) and
+// builds a *types.Package with the named types and interfaces it
+// declares, so it can be handed to the same *types.Interface translator
+// used by the gc reflect backend.
+//
+// Parse supports the subset of the gccgo export grammar needed to
+// resolve named interface types and their method sets: the package
+// clause, named type declarations, and the type literals that commonly
+// appear in interface method signatures (basic types, pointers, slices,
+// arrays, maps, channels, funcs, interfaces and backreferences to
+// earlier type declarations). Function bodies, inlinable bodies
+// (`<inl:...>`) and escape-analysis tags are skipped unparsed, as the
+// export format's own spec allows.
+func Parse(data []byte, importPath string) (pkg *types.Package, err error) {
+	p := &parser{s: newScanner(string(data)), types: map[int]types.Type{}}
+	defer func() {
+		if r := recover(); r != nil {
+			if perr, ok := r.(error); ok {
+				err = perr
+				return
+			}
+			panic(r)
+		}
+	}()
+	return p.parsePackage(importPath)
+}
+
+type parser struct {
+	s     *scanner
+	pkg   *types.Package
+	types map[int]types.Type // numbered type declarations, "type 5 ..."
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("gccgoimporter: %s (near %q)", fmt.Sprintf(format, args...), p.s.text())
+}
+
+func (p *parser) parsePackage(importPath string) (*types.Package, error) {
+	p.expect("package")
+	name := p.expectIdent()
+	// The package prefix (used to disambiguate multiple packages with
+	// the same name) is irrelevant to mockgen and discarded.
+	p.s.next()
+	p.expect(";")
+
+	p.pkg = types.NewPackage(importPath, name)
+
+	for !p.s.done() {
+		switch tok := p.s.peek(); {
+		case tok == "import":
+			p.skipStatement()
+		case tok == "type":
+			if err := p.parseTypeDecl(); err != nil {
+				return nil, err
+			}
+		case tok == "func" || tok == "var" || tok == "const":
+			p.skipStatement()
+		default:
+			// Unknown top-level construct (checksums, pragmas, ...);
+			// skip to the next statement rather than fail the whole
+			// package just because mockgen doesn't need it.
+			p.skipStatement()
+		}
+	}
+
+	p.pkg.MarkComplete()
+	return p.pkg, nil
+}
+
+// skipStatement consumes tokens up to and including the next top-level
+// ";", tracking brace/paren/angle-bracket nesting so embedded ones
+// (e.g. in a function body or an <inl:...> annotation) don't terminate
+// it early.
+func (p *parser) skipStatement() {
+	depth := 0
+	for !p.s.done() {
+		tok := p.s.next()
+		switch tok {
+		case "{", "(", "<":
+			depth++
+		case "}", ")", ">":
+			depth--
+		case ";":
+			if depth <= 0 {
+				return
+			}
+		}
+	}
+}
+
+func (p *parser) parseTypeDecl() error {
+	p.expect("type")
+	num, err := strconv.Atoi(p.expectIdent())
+	if err != nil {
+		return p.errorf("type declarations are numbered: %v", err)
+	}
+	qualified := p.expectString() // "importpath.Name"
+	name := qualified
+	if i := strings.LastIndexByte(qualified, '.'); i >= 0 {
+		name = qualified[i+1:]
+	}
+
+	// Reserve the number before parsing the underlying type, so that a
+	// self-referential type (an interface whose methods mention the
+	// interface itself) can still resolve its own backreference. A
+	// reference to a type whose declaration comes later in the data is
+	// not resolved and is reported as an error.
+	obj := types.NewTypeName(0, p.pkg, name, nil)
+	named := types.NewNamed(obj, nil, nil)
+	p.types[num] = named
+
+	underlying, err := p.parseType()
+	if err != nil {
+		return err
+	}
+	named.SetUnderlying(underlying)
+	p.pkg.Scope().Insert(obj)
+	p.expect(";")
+	return nil
+}
+
+// parseType parses a single type literal, one of the kinds listed in
+// Parse's doc comment.
+func (p *parser) parseType() (types.Type, error) {
+	switch tok := p.s.next(); tok {
+	case "<":
+		p.expect("type")
+		num, err := strconv.Atoi(p.expectIdent())
+		if err != nil {
+			return nil, p.errorf("backreference: %v", err)
+		}
+		p.expect(">")
+		t, ok := p.types[num]
+		if !ok {
+			return nil, p.errorf("backreference to undeclared type %d", num)
+		}
+		return t, nil
+	case "*":
+		elem, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		return types.NewPointer(elem), nil
+	case "[":
+		if p.s.peek() == "]" {
+			p.s.next()
+			elem, err := p.parseType()
+			if err != nil {
+				return nil, err
+			}
+			return types.NewSlice(elem), nil
+		}
+		n, err := strconv.ParseInt(p.expectIdent(), 10, 64)
+		if err != nil {
+			return nil, p.errorf("array length: %v", err)
+		}
+		p.expect("]")
+		elem, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		return types.NewArray(elem, n), nil
+	case "map":
+		p.expect("[")
+		key, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		p.expect("]")
+		val, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		return types.NewMap(key, val), nil
+	case "chan":
+		dir := types.SendRecv
+		switch p.s.peek() {
+		case "<-":
+			p.s.next()
+			dir = types.SendOnly
+		}
+		elem, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		return types.NewChan(dir, elem), nil
+	case "<-":
+		p.expect("chan")
+		elem, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		return types.NewChan(types.RecvOnly, elem), nil
+	case "func":
+		return p.parseSignature()
+	case "interface":
+		return p.parseInterface()
+	default:
+		if basic := lookupBasic(tok); basic != nil {
+			return basic, nil
+		}
+		return nil, p.errorf("unsupported type literal starting with %q", tok)
+	}
+}
+
+func (p *parser) parseSignature() (*types.Signature, error) {
+	params, variadic, err := p.parseParamList()
+	if err != nil {
+		return nil, err
+	}
+	var results []*types.Var
+	if p.s.peek() == "(" {
+		results, _, err = p.parseParamList()
+		if err != nil {
+			return nil, err
+		}
+	} else if tok := p.s.peek(); tok != ";" && tok != "" && tok != ")" && tok != "," {
+		t, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		results = []*types.Var{types.NewVar(0, nil, "", t)}
+	}
+	return types.NewSignatureType(nil, nil, nil, types.NewTuple(params...), types.NewTuple(results...), variadic), nil
+}
+
+func (p *parser) parseParamList() ([]*types.Var, bool, error) {
+	p.expect("(")
+	var params []*types.Var
+	variadic := false
+	for p.s.peek() != ")" {
+		if p.s.peek() == "..." {
+			p.s.next()
+			variadic = true
+		}
+		t, err := p.parseType()
+		if err != nil {
+			return nil, false, err
+		}
+		if variadic {
+			t = types.NewSlice(t)
+		}
+		params = append(params, types.NewVar(0, nil, "", t))
+		if p.s.peek() == "," {
+			p.s.next()
+			continue
+		}
+		break
+	}
+	p.expect(")")
+	return params, variadic, nil
+}
+
+func (p *parser) parseInterface() (*types.Interface, error) {
+	p.expect("{")
+	var methods []*types.Func
+	for p.s.peek() != "}" {
+		name := p.expectIdent()
+		sig, err := p.parseSignature()
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, types.NewFunc(0, p.pkg, name, sig))
+		// Inline-body and escape-analysis annotations trail the
+		// signature as a parenthesised/angle-bracketed blob; the
+		// export format spec explicitly allows skipping them.
+		for p.s.peek() == "<" || p.s.peek() == "(" {
+			p.skipBalanced()
+		}
+		if p.s.peek() == ";" {
+			p.s.next()
+		}
+	}
+	p.expect("}")
+	return types.NewInterfaceType(methods, nil), nil
+}
+
+func (p *parser) skipBalanced() {
+	open := p.s.next()
+	close := map[string]string{"<": ">", "(": ")", "{": "}"}[open]
+	depth := 1
+	for depth > 0 && !p.s.done() {
+		switch p.s.next() {
+		case open:
+			depth++
+		case close:
+			depth--
+		}
+	}
+}
+
+func (p *parser) expect(tok string) {
+	got := p.s.next()
+	if got != tok {
+		panic(p.errorf("expected %q, got %q", tok, got))
+	}
+}
+
+func (p *parser) expectIdent() string {
+	return p.s.next()
+}
+
+func (p *parser) expectString() string {
+	tok := p.s.next()
+	return strings.Trim(tok, `"`)
+}
+
+// lookupBasic resolves a predeclared identifier (int, string, error, ...)
+// to its types.Type via the universe scope, so callers don't need their
+// own copy of the predeclared type table.
+func lookupBasic(name string) types.Type {
+	obj := types.Universe.Lookup(name)
+	tn, ok := obj.(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	return tn.Type()
+}
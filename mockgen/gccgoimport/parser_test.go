@@ -0,0 +1,69 @@
+// Copyright 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gccgoimport
+
+import (
+	"go/types"
+	"testing"
+)
+
+const fixture = `v1;
+package foo bar;
+type 1 "foo.Greeter" interface { Greet (string) (string, error); };
+`
+
+func TestParseInterface(t *testing.T) {
+	version, payload, err := SplitVersion([]byte(fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != V1 {
+		t.Fatalf("got version %v, want %v", version, V1)
+	}
+
+	pkg, err := Parse(payload, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	obj := pkg.Scope().Lookup("Greeter")
+	if obj == nil {
+		t.Fatal("Greeter not found in parsed package")
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		t.Fatalf("Greeter is a %T, want *types.Named", obj.Type())
+	}
+	iface, ok := named.Underlying().(*types.Interface)
+	if !ok {
+		t.Fatalf("Greeter's underlying type is %T, want *types.Interface", named.Underlying())
+	}
+	if iface.NumMethods() != 1 {
+		t.Fatalf("got %d methods, want 1", iface.NumMethods())
+	}
+	if got := iface.Method(0).Name(); got != "Greet" {
+		t.Fatalf("got method %q, want Greet", got)
+	}
+	sig := iface.Method(0).Type().(*types.Signature)
+	if sig.Params().Len() != 1 || sig.Results().Len() != 2 {
+		t.Fatalf("got signature %v, want (string) (string, error)", sig)
+	}
+}
+
+func TestSplitVersionRejectsUnknownHeader(t *testing.T) {
+	if _, _, err := SplitVersion([]byte("not export data")); err == nil {
+		t.Fatal("expected an error for data with no version header")
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gccgoimport
+
+import "strings"
+
+// scanner tokenizes gccgo export data. It has no notion of the grammar;
+// it just splits the input into identifiers/numbers, quoted strings, and
+// punctuation, which is enough for parser.go's recursive descent.
+type scanner struct {
+	src  string
+	pos  int
+	toks []string
+	i    int
+}
+
+func newScanner(src string) *scanner {
+	s := &scanner{src: src}
+	s.tokenize()
+	return s
+}
+
+func (s *scanner) tokenize() {
+	i := 0
+	for i < len(s.src) {
+		c := s.src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(s.src) && s.src[j] != '"' {
+				if s.src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j++ // closing quote
+			if j > len(s.src) {
+				j = len(s.src)
+			}
+			s.toks = append(s.toks, s.src[i:j])
+			i = j
+		case strings.HasPrefix(s.src[i:], "<-"):
+			s.toks = append(s.toks, "<-")
+			i += 2
+		case strings.HasPrefix(s.src[i:], "..."):
+			s.toks = append(s.toks, "...")
+			i += 3
+		case strings.ContainsRune(";{}()[]<>*,.", rune(c)):
+			s.toks = append(s.toks, string(c))
+			i++
+		default:
+			j := i
+			for j < len(s.src) && !strings.ContainsAny(s.src[j:j+1], " \t\n\r\"{}()[]<>*,;") {
+				j++
+			}
+			if j == i {
+				j++ // never get stuck on an unrecognized byte
+			}
+			s.toks = append(s.toks, s.src[i:j])
+			i = j
+		}
+	}
+}
+
+func (s *scanner) done() bool { return s.i >= len(s.toks) }
+
+func (s *scanner) peek() string {
+	if s.done() {
+		return ""
+	}
+	return s.toks[s.i]
+}
+
+func (s *scanner) next() string {
+	if s.done() {
+		return ""
+	}
+	t := s.toks[s.i]
+	s.i++
+	return t
+}
+
+// text returns a short window around the current position, for error
+// messages.
+func (s *scanner) text() string {
+	lo := s.i - 2
+	if lo < 0 {
+		lo = 0
+	}
+	hi := s.i + 3
+	if hi > len(s.toks) {
+		hi = len(s.toks)
+	}
+	return strings.Join(s.toks[lo:hi], " ")
+}
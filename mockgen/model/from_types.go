@@ -0,0 +1,223 @@
+// Copyright 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"go/types"
+)
+
+// InterfaceFromTypesInterface builds a model.Interface from a go/types
+// representation of an interface, as produced by the packages-based
+// reflect backend. name is the declared name of the interface (the
+// *types.Named wrapping iface, if any, is not required). qual is used to
+// qualify package-scoped identifiers the same way go/types' own
+// printers do (see types.Qualifier).
+func InterfaceFromTypesInterface(name string, iface *types.Interface, qual types.Qualifier) (*Interface, error) {
+	iface = iface.Complete()
+	intf := &Interface{Name: name}
+	for i := 0; i < iface.NumMethods(); i++ {
+		fn := iface.Method(i)
+		sig, ok := fn.Type().(*types.Signature)
+		if !ok {
+			return nil, fmt.Errorf("method %v has non-func type %v", fn.Name(), fn.Type())
+		}
+		m, err := methodFromSignature(fn.Name(), sig, qual)
+		if err != nil {
+			return nil, fmt.Errorf("method %s: %v", fn.Name(), err)
+		}
+		intf.Methods = append(intf.Methods, m)
+	}
+	return intf, nil
+}
+
+// ParamsFromTypeParams translates a *types.Named's own type parameter
+// list (as returned by types.Named.TypeParams, nil on interfaces that
+// aren't generic) into model.Params for model.Interface.TypeParams.
+func ParamsFromTypeParams(tparams *types.TypeParamList, qual types.Qualifier) ([]*Param, error) {
+	if tparams == nil {
+		return nil, nil
+	}
+	out := make([]*Param, tparams.Len())
+	for i := 0; i < tparams.Len(); i++ {
+		tp := tparams.At(i)
+		constraint, err := typeFromTypesType(tp.Constraint(), qual)
+		if err != nil {
+			return nil, fmt.Errorf("type parameter %s: %v", tp.Obj().Name(), err)
+		}
+		out[i] = &Param{Name: tp.Obj().Name(), Constraint: constraint}
+	}
+	return out, nil
+}
+
+func methodFromSignature(name string, sig *types.Signature, qual types.Qualifier) (*Method, error) {
+	m := &Method{Name: name}
+
+	params := sig.Params()
+	n := params.Len()
+	if sig.Variadic() {
+		n--
+	}
+	for i := 0; i < n; i++ {
+		t, err := typeFromTypesType(params.At(i).Type(), qual)
+		if err != nil {
+			return nil, err
+		}
+		m.In = append(m.In, &Parameter{Name: params.At(i).Name(), Type: t})
+	}
+	if sig.Variadic() {
+		last := params.At(n)
+		elemT, ok := last.Type().(*types.Slice)
+		if !ok {
+			return nil, fmt.Errorf("variadic parameter %v is not a slice", last)
+		}
+		t, err := typeFromTypesType(elemT.Elem(), qual)
+		if err != nil {
+			return nil, err
+		}
+		m.Variadic = &Parameter{Name: last.Name(), Type: t}
+	}
+
+	results := sig.Results()
+	for i := 0; i < results.Len(); i++ {
+		t, err := typeFromTypesType(results.At(i).Type(), qual)
+		if err != nil {
+			return nil, err
+		}
+		m.Out = append(m.Out, &Parameter{Name: results.At(i).Name(), Type: t})
+	}
+	return m, nil
+}
+
+// typeFromTypesType converts a go/types.Type into a model.Type,
+// qualifying named types from other packages with qual.
+func typeFromTypesType(t types.Type, qual types.Qualifier) (Type, error) {
+	switch t := t.(type) {
+	case *types.Array:
+		elem, err := typeFromTypesType(t.Elem(), qual)
+		if err != nil {
+			return nil, err
+		}
+		return &ArrayType{Len: int(t.Len()), Type: elem}, nil
+	case *types.Slice:
+		elem, err := typeFromTypesType(t.Elem(), qual)
+		if err != nil {
+			return nil, err
+		}
+		return &ArrayType{Len: -1, Type: elem}, nil
+	case *types.Chan:
+		elem, err := typeFromTypesType(t.Elem(), qual)
+		if err != nil {
+			return nil, err
+		}
+		dir := BothDir
+		switch t.Dir() {
+		case types.RecvOnly:
+			dir = RecvDir
+		case types.SendOnly:
+			dir = SendDir
+		}
+		return &ChanType{Dir: dir, Type: elem}, nil
+	case *types.Map:
+		k, err := typeFromTypesType(t.Key(), qual)
+		if err != nil {
+			return nil, err
+		}
+		v, err := typeFromTypesType(t.Elem(), qual)
+		if err != nil {
+			return nil, err
+		}
+		return &MapType{Key: k, Value: v}, nil
+	case *types.Pointer:
+		elem, err := typeFromTypesType(t.Elem(), qual)
+		if err != nil {
+			return nil, err
+		}
+		return &PointerType{Type: elem}, nil
+	case *types.Signature:
+		return funcTypeFromSignature(t, qual)
+	case *types.Named:
+		obj := t.Obj()
+		pkgPath := ""
+		if obj.Pkg() != nil {
+			if p := qual(obj.Pkg()); p != "" {
+				pkgPath = obj.Pkg().Path()
+			}
+		}
+		var targs []Type
+		if ta := t.TypeArgs(); ta != nil {
+			targs = make([]Type, ta.Len())
+			for i := 0; i < ta.Len(); i++ {
+				arg, err := typeFromTypesType(ta.At(i), qual)
+				if err != nil {
+					return nil, fmt.Errorf("type argument %d of %v: %v", i, obj.Name(), err)
+				}
+				targs[i] = arg
+			}
+		}
+		return &NamedType{Package: pkgPath, Type: obj.Name(), TypeArgs: targs}, nil
+	case *types.TypeParam:
+		// A reference to an enclosing interface's own type parameter
+		// (e.g. the T in Get(id string) (T, error)); there is no
+		// concrete package to qualify it with.
+		return PredeclaredType(t.Obj().Name()), nil
+	case *types.Basic:
+		return PredeclaredType(t.Name()), nil
+	case *types.Interface:
+		if t.NumMethods() == 0 {
+			return PredeclaredType("interface{}"), nil
+		}
+		return PredeclaredType(types.TypeString(t, qual)), nil
+	default:
+		return PredeclaredType(types.TypeString(t, qual)), nil
+	}
+}
+
+func funcTypeFromSignature(sig *types.Signature, qual types.Qualifier) (Type, error) {
+	ft := &FuncType{}
+	params := sig.Params()
+	n := params.Len()
+	if sig.Variadic() {
+		n--
+	}
+	for i := 0; i < n; i++ {
+		t, err := typeFromTypesType(params.At(i).Type(), qual)
+		if err != nil {
+			return nil, err
+		}
+		ft.In = append(ft.In, &Parameter{Name: params.At(i).Name(), Type: t})
+	}
+	if sig.Variadic() {
+		last := params.At(n)
+		elemT, ok := last.Type().(*types.Slice)
+		if !ok {
+			return nil, fmt.Errorf("variadic parameter %v is not a slice", last)
+		}
+		t, err := typeFromTypesType(elemT.Elem(), qual)
+		if err != nil {
+			return nil, err
+		}
+		ft.Variadic = &Parameter{Name: last.Name(), Type: t}
+	}
+	results := sig.Results()
+	for i := 0; i < results.Len(); i++ {
+		t, err := typeFromTypesType(results.At(i).Type(), qual)
+		if err != nil {
+			return nil, err
+		}
+		ft.Out = append(ft.Out, &Parameter{Name: results.At(i).Name(), Type: t})
+	}
+	return ft, nil
+}
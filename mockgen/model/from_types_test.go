@@ -0,0 +1,213 @@
+// Copyright 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+)
+
+// checkFixture type-checks src as a standalone package named "fixture"
+// and returns the resulting *types.Package.
+func checkFixture(t *testing.T, src string) *types.Package {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "fixture.go", src, 0)
+	if err != nil {
+		t.Fatalf("parsing fixture: %v", err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("fixture", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatalf("type-checking fixture: %v", err)
+	}
+	return pkg
+}
+
+// lookupInterface finds name in pkg and returns its *types.Named together
+// with the *types.Interface it denotes, failing the test if either step
+// doesn't hold.
+func lookupInterface(t *testing.T, pkg *types.Package, name string) (*types.Named, *types.Interface) {
+	t.Helper()
+	obj := pkg.Scope().Lookup(name)
+	if obj == nil {
+		t.Fatalf("%s not found in fixture", name)
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		t.Fatalf("%s is a %T, want *types.Named", name, obj.Type())
+	}
+	iface, ok := named.Underlying().(*types.Interface)
+	if !ok {
+		t.Fatalf("%s's underlying type is %T, want *types.Interface", name, named.Underlying())
+	}
+	return named, iface
+}
+
+func TestInterfaceFromTypesInterface_Basic(t *testing.T) {
+	pkg := checkFixture(t, `
+package fixture
+
+type Basic interface {
+	Add(a, b int) int
+	Name() string
+}
+`)
+	_, iface := lookupInterface(t, pkg, "Basic")
+	intf, err := InterfaceFromTypesInterface("Basic", iface, types.RelativeTo(pkg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if intf.Name != "Basic" {
+		t.Fatalf("got name %q, want Basic", intf.Name)
+	}
+	if len(intf.Methods) != 2 {
+		t.Fatalf("got %d methods, want 2", len(intf.Methods))
+	}
+	add := intf.Methods[0]
+	if add.Name != "Add" || len(add.In) != 2 || len(add.Out) != 1 {
+		t.Fatalf("got method %+v, want Add(int, int) int", add)
+	}
+	if got := add.In[0].Type.String(nil, ""); got != "int" {
+		t.Fatalf("got param type %q, want int", got)
+	}
+}
+
+func TestTypeFromTypesType_NamedTypeArgs(t *testing.T) {
+	pkg := checkFixture(t, `
+package fixture
+
+type Box[T any] struct {
+	Val T
+}
+
+type Thing interface {
+	Get(id string) (Box[int], error)
+}
+`)
+	_, iface := lookupInterface(t, pkg, "Thing")
+	intf, err := InterfaceFromTypesInterface("Thing", iface, types.RelativeTo(pkg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := intf.Methods[0]
+	if len(get.Out) != 2 {
+		t.Fatalf("got %d results, want 2", len(get.Out))
+	}
+	box, ok := get.Out[0].Type.(*NamedType)
+	if !ok {
+		t.Fatalf("got result type %T, want *NamedType", get.Out[0].Type)
+	}
+	if box.Type != "Box" {
+		t.Fatalf("got named type %q, want Box", box.Type)
+	}
+	if len(box.TypeArgs) != 1 || box.TypeArgs[0].String(nil, "") != "int" {
+		t.Fatalf("got TypeArgs %+v, want [int]", box.TypeArgs)
+	}
+	if got, want := box.String(nil, ""), "Box[int]"; got != want {
+		t.Fatalf("got rendered type %q, want %q", got, want)
+	}
+}
+
+func TestParamsFromTypeParams_Constraint(t *testing.T) {
+	pkg := checkFixture(t, `
+package fixture
+
+type Ordered interface {
+	~int | ~string
+}
+
+type Repo[T Ordered] interface {
+	Get(id string) (T, error)
+}
+`)
+	named, _ := lookupInterface(t, pkg, "Repo")
+	params, err := ParamsFromTypeParams(named.TypeParams(), types.RelativeTo(pkg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(params) != 1 {
+		t.Fatalf("got %d type params, want 1", len(params))
+	}
+	if params[0].Name != "T" {
+		t.Fatalf("got param name %q, want T", params[0].Name)
+	}
+	if got, want := params[0].Constraint.String(nil, ""), "Ordered"; got != want {
+		t.Fatalf("got constraint %q, want %q", got, want)
+	}
+}
+
+func TestInterfaceFromTypesInterface_EmbeddedGeneric(t *testing.T) {
+	pkg := checkFixture(t, `
+package fixture
+
+type Reader[T any] interface {
+	Read() (T, error)
+}
+
+type ReadWriter[T any] interface {
+	Reader[T]
+	Write(T) error
+}
+`)
+	named, iface := lookupInterface(t, pkg, "ReadWriter")
+	qual := types.RelativeTo(pkg)
+	intf, err := InterfaceFromTypesInterface("ReadWriter", iface, qual)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	methods := map[string]bool{}
+	for _, m := range intf.Methods {
+		methods[m.Name] = true
+	}
+	if !methods["Read"] || !methods["Write"] {
+		t.Fatalf("got methods %v, want both Read (from the embedded Reader[T]) and Write", intf.Methods)
+	}
+
+	params, err := ParamsFromTypeParams(named.TypeParams(), qual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(params) != 1 || params[0].Name != "T" {
+		t.Fatalf("got type params %+v, want one param named T", params)
+	}
+}
+
+func TestTypeFromTypesType_MethodReferencingTypeParam(t *testing.T) {
+	pkg := checkFixture(t, `
+package fixture
+
+type Repo[T any] interface {
+	Get(id string) (T, error)
+}
+`)
+	_, iface := lookupInterface(t, pkg, "Repo")
+	intf, err := InterfaceFromTypesInterface("Repo", iface, types.RelativeTo(pkg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := intf.Methods[0]
+	if len(get.Out) != 2 {
+		t.Fatalf("got %d results, want 2", len(get.Out))
+	}
+	if got, want := get.Out[0].Type.String(nil, ""), "T"; got != want {
+		t.Fatalf("got result type %q, want %q (the interface's own type parameter)", got, want)
+	}
+}
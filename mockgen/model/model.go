@@ -0,0 +1,270 @@
+// Copyright 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package model contains the data model necessary for generating mock implementations.
+package model
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Package is a Go package. It may be a subset.
+type Package struct {
+	Name       string
+	Interfaces []*Interface
+	DotImports []string
+}
+
+// Interface is a Go interface.
+type Interface struct {
+	Name    string
+	Methods []*Method
+
+	// TypeParams holds the interface's own type parameters, e.g. the T
+	// in `type Repo[T any] interface{...}`. It is nil for non-generic
+	// interfaces.
+	TypeParams []*Param
+}
+
+// Param is a type parameter of a generic interface, e.g. the "T any" in
+// Repo[T any].
+type Param struct {
+	Name       string
+	Constraint Type
+}
+
+// AddMethod adds a method. It returns an error, and leaves the method
+// set unchanged, if a method with the same name is already present.
+func (i *Interface) AddMethod(m *Method) error {
+	for _, me := range i.Methods {
+		if me.Name == m.Name {
+			return fmt.Errorf("duplicate method %q", m.Name)
+		}
+	}
+	i.Methods = append(i.Methods, m)
+	return nil
+}
+
+// Method is a method of an interface.
+type Method struct {
+	Name     string
+	In, Out  []*Parameter
+	Variadic *Parameter // may be nil
+}
+
+// Parameter is an argument or return parameter of a method.
+type Parameter struct {
+	Name string // may be empty
+	Type Type
+}
+
+// Type is a Go type.
+type Type interface {
+	String(pm map[string]string, pkgOverride string) string
+
+	// addImports adds the imports needed by this type to the given import set.
+	addImports(im map[string]bool)
+}
+
+func (pkg *Package) Print(w io.Writer) {
+	fmt.Fprintf(w, "package %s\n", pkg.Name)
+	for _, intf := range pkg.Interfaces {
+		fmt.Fprintf(w, "interface %s {\n", intf.Name)
+		for _, m := range intf.Methods {
+			fmt.Fprintf(w, "  %v\n", m)
+		}
+		fmt.Fprintf(w, "}\n")
+	}
+}
+
+func (m *Method) String() string {
+	args := make([]string, len(m.In))
+	for i, p := range m.In {
+		args[i] = p.Type.String(nil, "")
+	}
+	if m.Variadic != nil {
+		args = append(args, "..."+m.Variadic.Type.String(nil, ""))
+	}
+	rets := make([]string, len(m.Out))
+	for i, p := range m.Out {
+		rets[i] = p.Type.String(nil, "")
+	}
+	out := strings.Join(rets, ", ")
+	if len(rets) > 1 {
+		out = "(" + out + ")"
+	}
+	return fmt.Sprintf("%v(%v) %v", m.Name, strings.Join(args, ", "), out)
+}
+
+// PredeclaredType is a predeclared type such as "int".
+type PredeclaredType string
+
+func (pt PredeclaredType) String(pm map[string]string, pkgOverride string) string { return string(pt) }
+func (pt PredeclaredType) addImports(im map[string]bool)                          {}
+
+// ArrayType is an array or slice type.
+type ArrayType struct {
+	Len  int // -1 for slices, >= 0 for arrays
+	Type Type
+}
+
+func (at *ArrayType) String(pm map[string]string, pkgOverride string) string {
+	s := "[]"
+	if at.Len > -1 {
+		s = fmt.Sprintf("[%d]", at.Len)
+	}
+	return s + at.Type.String(pm, pkgOverride)
+}
+
+func (at *ArrayType) addImports(im map[string]bool) { at.Type.addImports(im) }
+
+// ChanType is a channel type.
+type ChanType struct {
+	Dir  ChanDir // 0, 1 or 2
+	Type Type
+}
+
+// ChanDir is a channel direction.
+type ChanDir int
+
+const (
+	BothDir ChanDir = iota
+	RecvDir
+	SendDir
+)
+
+func (ct *ChanType) String(pm map[string]string, pkgOverride string) string {
+	s := ct.Type.String(pm, pkgOverride)
+	switch ct.Dir {
+	case RecvDir:
+		return "<-chan " + s
+	case SendDir:
+		return "chan<- " + s
+	}
+	return "chan " + s
+}
+
+func (ct *ChanType) addImports(im map[string]bool) { ct.Type.addImports(im) }
+
+// FuncType is a function type.
+type FuncType struct {
+	In, Out  []*Parameter
+	Variadic *Parameter // may be nil
+}
+
+func (ft *FuncType) String(pm map[string]string, pkgOverride string) string {
+	args := make([]string, len(ft.In))
+	for i, p := range ft.In {
+		args[i] = p.Type.String(pm, pkgOverride)
+	}
+	if ft.Variadic != nil {
+		args = append(args, "..."+ft.Variadic.Type.String(pm, pkgOverride))
+	}
+	rets := make([]string, len(ft.Out))
+	for i, p := range ft.Out {
+		rets[i] = p.Type.String(pm, pkgOverride)
+	}
+	out := strings.Join(rets, ", ")
+	if len(rets) > 1 {
+		out = "(" + out + ")"
+	}
+	return fmt.Sprintf("func(%v) %v", strings.Join(args, ", "), out)
+}
+
+func (ft *FuncType) addImports(im map[string]bool) {
+	for _, p := range ft.In {
+		p.Type.addImports(im)
+	}
+	for _, p := range ft.Out {
+		p.Type.addImports(im)
+	}
+	if ft.Variadic != nil {
+		ft.Variadic.Type.addImports(im)
+	}
+}
+
+// MapType is a map type.
+type MapType struct {
+	Key, Value Type
+}
+
+func (mt *MapType) String(pm map[string]string, pkgOverride string) string {
+	return fmt.Sprintf("map[%v]%v", mt.Key.String(pm, pkgOverride), mt.Value.String(pm, pkgOverride))
+}
+
+func (mt *MapType) addImports(im map[string]bool) {
+	mt.Key.addImports(im)
+	mt.Value.addImports(im)
+}
+
+// NamedType is an exported type in a package.
+type NamedType struct {
+	Package string // may be empty
+	Type    string
+
+	// TypeArgs holds the concrete type arguments of an instantiated
+	// generic named type, e.g. the int in Box[int] referenced from a
+	// method signature. It is nil for a non-generic type.
+	TypeArgs []Type
+}
+
+func (nt *NamedType) String(pm map[string]string, pkgOverride string) string {
+	name := nt.Type
+	if nt.Package != "" && nt.Package != pkgOverride {
+		if prefix := pm[nt.Package]; prefix != "" {
+			name = prefix + "." + nt.Type
+		}
+	}
+	if len(nt.TypeArgs) == 0 {
+		return name
+	}
+	args := make([]string, len(nt.TypeArgs))
+	for i, a := range nt.TypeArgs {
+		args[i] = a.String(pm, pkgOverride)
+	}
+	return name + "[" + strings.Join(args, ", ") + "]"
+}
+
+func (nt *NamedType) addImports(im map[string]bool) {
+	if nt.Package != "" {
+		im[nt.Package] = true
+	}
+	for _, a := range nt.TypeArgs {
+		a.addImports(im)
+	}
+}
+
+// PointerType is a pointer type.
+type PointerType struct {
+	Type Type
+}
+
+func (pt *PointerType) String(pm map[string]string, pkgOverride string) string {
+	return "*" + pt.Type.String(pm, pkgOverride)
+}
+
+func (pt *PointerType) addImports(im map[string]bool) { pt.Type.addImports(im) }
+
+func init() {
+	gob.Register(&ArrayType{})
+	gob.Register(&ChanType{})
+	gob.Register(&FuncType{})
+	gob.Register(&MapType{})
+	gob.Register(&NamedType{})
+	gob.Register(&PointerType{})
+	gob.Register(PredeclaredType(""))
+}
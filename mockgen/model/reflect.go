@@ -0,0 +1,155 @@
+// Copyright 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// InterfaceFromInterfaceType returns a model.Interface for the given
+// reflect.Interface type. The type should be obtained with
+// reflect.TypeOf((*SomeInterface)(nil)).Elem().
+func InterfaceFromInterfaceType(it reflect.Type) (*Interface, error) {
+	if it.Kind() != reflect.Interface {
+		return nil, fmt.Errorf("%v is not an interface", it)
+	}
+	intf := &Interface{}
+
+	for i := 0; i < it.NumMethod(); i++ {
+		m := it.Method(i)
+		mm, err := funcFromMethod(m)
+		if err != nil {
+			return nil, fmt.Errorf("reflecting method %v: %v", m.Name, err)
+		}
+		mm.Name = m.Name
+		intf.Methods = append(intf.Methods, mm)
+	}
+	return intf, nil
+}
+
+func funcFromMethod(m reflect.Method) (*Method, error) {
+	ft := m.Type
+	meth := &Method{}
+
+	nIn := ft.NumIn()
+	if ft.IsVariadic() {
+		nIn--
+	}
+	for i := 0; i < nIn; i++ {
+		t, err := typeFromType(ft.In(i))
+		if err != nil {
+			return nil, err
+		}
+		meth.In = append(meth.In, &Parameter{Type: t})
+	}
+	if ft.IsVariadic() {
+		t, err := typeFromType(ft.In(nIn).Elem())
+		if err != nil {
+			return nil, err
+		}
+		meth.Variadic = &Parameter{Type: t}
+	}
+	for i := 0; i < ft.NumOut(); i++ {
+		t, err := typeFromType(ft.Out(i))
+		if err != nil {
+			return nil, err
+		}
+		meth.Out = append(meth.Out, &Parameter{Type: t})
+	}
+	return meth, nil
+}
+
+func typeFromType(t reflect.Type) (Type, error) {
+	switch t.Kind() {
+	case reflect.Array, reflect.Slice:
+		elem, err := typeFromType(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		length := -1
+		if t.Kind() == reflect.Array {
+			length = t.Len()
+		}
+		return &ArrayType{Len: length, Type: elem}, nil
+	case reflect.Chan:
+		elem, err := typeFromType(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		var dir ChanDir
+		switch t.ChanDir() {
+		case reflect.RecvDir:
+			dir = RecvDir
+		case reflect.SendDir:
+			dir = SendDir
+		}
+		return &ChanType{Dir: dir, Type: elem}, nil
+	case reflect.Map:
+		k, err := typeFromType(t.Key())
+		if err != nil {
+			return nil, err
+		}
+		v, err := typeFromType(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &MapType{Key: k, Value: v}, nil
+	case reflect.Ptr:
+		elem, err := typeFromType(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &PointerType{Type: elem}, nil
+	case reflect.Struct, reflect.Interface:
+		if t.Name() == "" {
+			return nil, fmt.Errorf("anonymous type %v is not supported", t)
+		}
+		return &NamedType{Package: t.PkgPath(), Type: t.Name()}, nil
+	case reflect.Func:
+		ft := &FuncType{}
+		nIn := t.NumIn()
+		if t.IsVariadic() {
+			nIn--
+		}
+		for i := 0; i < nIn; i++ {
+			pt, err := typeFromType(t.In(i))
+			if err != nil {
+				return nil, err
+			}
+			ft.In = append(ft.In, &Parameter{Type: pt})
+		}
+		if t.IsVariadic() {
+			pt, err := typeFromType(t.In(nIn).Elem())
+			if err != nil {
+				return nil, err
+			}
+			ft.Variadic = &Parameter{Type: pt}
+		}
+		for i := 0; i < t.NumOut(); i++ {
+			pt, err := typeFromType(t.Out(i))
+			if err != nil {
+				return nil, err
+			}
+			ft.Out = append(ft.Out, &Parameter{Type: pt})
+		}
+		return ft, nil
+	default:
+		if t.PkgPath() != "" {
+			return &NamedType{Package: t.PkgPath(), Type: t.Name()}, nil
+		}
+		return PredeclaredType(t.String()), nil
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+// This file reads -overlay files, which let mockgen reflect on source
+// that hasn't been saved to disk yet (e.g. an editor's in-memory buffer).
+// The file format is the same one `go build -overlay` uses: a JSON object
+// whose "Replace" field maps an on-disk path to the path of a file holding
+// its replacement content. golang.org/x/tools/go/packages.Config.Overlay
+// takes the same mapping with the replacement contents read in.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+type overlayJSON struct {
+	Replace map[string]string
+}
+
+// readOverlay parses the overlay file at path and reads the contents of
+// each replacement file, returning them keyed by the on-disk path they
+// replace, in the form packages.Config.Overlay expects.
+func readOverlay(path string) (map[string][]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading overlay %v: %v", path, err)
+	}
+	var o overlayJSON
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, fmt.Errorf("parsing overlay %v: %v", path, err)
+	}
+
+	overlay := make(map[string][]byte, len(o.Replace))
+	for orig, replacement := range o.Replace {
+		content, err := ioutil.ReadFile(replacement)
+		if err != nil {
+			return nil, fmt.Errorf("reading overlay replacement %v for %v: %v", replacement, orig, err)
+		}
+		overlay[orig] = content
+	}
+	return overlay, nil
+}
+
+// overlaySupporter is implemented by Builders that can pass an -overlay
+// file through to the underlying `go build` invocation.
+type overlaySupporter interface {
+	SupportOverlay(path string)
+}
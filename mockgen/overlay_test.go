@@ -0,0 +1,73 @@
+// Copyright 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadOverlay(t *testing.T) {
+	dir := t.TempDir()
+	replacement := filepath.Join(dir, "edited.go")
+	if err := os.WriteFile(replacement, []byte("package foo\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	overlayPath := filepath.Join(dir, "overlay.json")
+	contents := fmt.Sprintf(`{"Replace": {"/orig/foo.go": %q}}`, replacement)
+	if err := os.WriteFile(overlayPath, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	overlay, err := readOverlay(overlayPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := overlay["/orig/foo.go"]
+	if !ok {
+		t.Fatalf("got overlay %v, missing /orig/foo.go", overlay)
+	}
+	if string(got) != "package foo\n" {
+		t.Fatalf("got content %q, want %q", got, "package foo\n")
+	}
+}
+
+func TestReadOverlay_MissingReplacementFile(t *testing.T) {
+	dir := t.TempDir()
+	overlayPath := filepath.Join(dir, "overlay.json")
+	contents := fmt.Sprintf(`{"Replace": {"/orig/foo.go": %q}}`, filepath.Join(dir, "does-not-exist.go"))
+	if err := os.WriteFile(overlayPath, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := readOverlay(overlayPath); err == nil {
+		t.Fatal("expected an error for a replacement file that doesn't exist")
+	}
+}
+
+func TestReadOverlay_MalformedJSON(t *testing.T) {
+	dir := t.TempDir()
+	overlayPath := filepath.Join(dir, "overlay.json")
+	if err := os.WriteFile(overlayPath, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := readOverlay(overlayPath); err == nil {
+		t.Fatal("expected an error for malformed overlay JSON")
+	}
+}
@@ -20,23 +20,52 @@ import (
 	"bytes"
 	"encoding/gob"
 	"flag"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"text/template"
-	"strings"
 
 	"github.com/juno-lab/mock/mockgen/model"
 )
 
 var (
-	progOnly = flag.Bool("prog_only", false, "(reflect mode) Only generate the reflection program; write it to stdout.")
-	execOnly = flag.String("exec_only", "", "(reflect mode) If set, execute this reflection program.")
+	progOnly    = flag.Bool("prog_only", false, "(reflect mode) Only generate the reflection program; write it to stdout.")
+	execOnly    = flag.String("exec_only", "", "(reflect mode) If set, execute this reflection program.")
+	buildFlags  = flag.String("build_flags", "", "(reflect mode, exec backend) Additional flags for go build.")
+	reflectMode = flag.String("reflect_mode", "packages", "(reflect mode) Backend used to reflect on the target package: \"packages\" type-checks it in process via golang.org/x/tools/go/packages, \"exec\" builds and runs a throwaway program (the original, slower behavior), \"gccgo\" reads gccgo/gollvm export data directly. Left at its default, \"packages\" defers to \"gccgo\" automatically when the active `go` command wraps a gccgo/gollvm toolchain.")
+	overlay     = flag.String("overlay", "", "(reflect mode) Path to a JSON file in the same format as `go build -overlay`, letting mockgen reflect on unsaved edits to source files.")
 )
 
+// Reflect loads symbols, a set of interface names declared in importPath,
+// and returns a model.Package describing them.
 func Reflect(importPath string, symbols []string) (*model.Package, error) {
+	mode := *reflectMode
+	modeSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "reflect_mode" {
+			modeSet = true
+		}
+	})
+	if mode == "packages" && !modeSet && detectGccgoToolchain() {
+		mode = "gccgo"
+	}
+
+	switch mode {
+	case "packages":
+		return reflectPackages(importPath, symbols, *overlay)
+	case "exec":
+		return reflectExec(importPath, symbols)
+	case "gccgo":
+		return reflectGccgo(importPath, symbols)
+	default:
+		return nil, fmt.Errorf("unknown -reflect_mode %q", *reflectMode)
+	}
+}
+
+func reflectExec(importPath string, symbols []string) (*model.Package, error) {
 	// TODO: sanity check arguments
 
 	progPath := *execOnly
@@ -47,13 +76,33 @@ func Reflect(importPath string, symbols []string) (*model.Package, error) {
 			return nil, err
 		}
 		defer func() { os.RemoveAll(tmpDir) }()
+		// detectBuilder and Builder.Build compare tmpDir against an
+		// absolutized moduleDir (see goModulesBuilder.Build), so keep
+		// tmpDir absolute from here on rather than the "./gomock_reflect_…"
+		// path ioutil.TempDir(".", …) returns.
+		tmpDir, err = filepath.Abs(tmpDir)
+		if err != nil {
+			return nil, err
+		}
 		const progSource = "prog.go"
 
+		specs := make([]symbolSpec, len(symbols))
+		for i, sym := range symbols {
+			name, args, err := parseSymbolSpec(sym)
+			if err != nil {
+				return nil, err
+			}
+			if len(args) > 0 && !isConcreteTypeArgs(args) {
+				return nil, fmt.Errorf("symbol %q names type parameters rather than concrete type arguments; the exec backend can only reflect on a concrete instantiation, use -reflect_mode=packages instead", sym)
+			}
+			specs[i] = symbolSpec{Label: name, Expr: sym}
+		}
+
 		// Generate program.
 		var program bytes.Buffer
 		data := reflectData{
 			ImportPath: importPath,
-			Symbols:    symbols,
+			Symbols:    specs,
 		}
 		if err := reflectProgram.Execute(&program, &data); err != nil {
 			return nil, err
@@ -66,29 +115,24 @@ func Reflect(importPath string, symbols []string) (*model.Package, error) {
 			return nil, err
 		}
 
-		gbProjectDir, err := getGbInfo("GB_PROJECT_DIR")
+		builder, err := detectBuilder(tmpDir)
 		if err != nil {
 			return nil, err
 		}
 
-		gbBinSuffix, err := getGbInfo("GB_BIN_SUFFIX")
-		if err != nil {
-			return nil, err
+		if *overlay != "" {
+			supporter, ok := builder.(overlaySupporter)
+			if !ok {
+				return nil, fmt.Errorf("-overlay is not supported by the %T builder detected for this project", builder)
+			}
+			supporter.SupportOverlay(*overlay)
 		}
 
-		tmpDirName := filepath.Base(tmpDir)
-		progPath = filepath.Join(gbProjectDir, "bin", tmpDirName+gbBinSuffix)
-		defer os.Remove(progPath)
-
-		// Build the program.
-		cmd := exec.Command("gb", "build", tmpDir)
-		cmd.Dir = "."
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
+		progPath, err = builder.Build(tmpDir)
+		if err != nil {
 			return nil, err
 		}
-
+		defer os.Remove(progPath)
 	}
 
 	// Run it.
@@ -118,21 +162,18 @@ func Reflect(importPath string, symbols []string) (*model.Package, error) {
 	return &pkg, nil
 }
 
-func getGbInfo(param string) (string, error) {
-	cmd := exec.Command("gb", "info", param)
-	var stdout bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return "", err
-	}
-
-	return strings.Trim(stdout.String(), "\n"), nil
-}
-
 type reflectData struct {
 	ImportPath string
-	Symbols    []string
+	Symbols    []symbolSpec
+}
+
+// symbolSpec is a single -symbols entry split into the name used for the
+// generated mock (Label) and the Go type expression used to instantiate
+// it (Expr, which for a generic interface includes its concrete type
+// arguments, e.g. "Repo[int,string]").
+type symbolSpec struct {
+	Label string
+	Expr  string
 }
 
 // This program reflects on an interface value, and prints the
@@ -159,7 +200,7 @@ func main() {
 		typ reflect.Type
 	}{
 		{{range .Symbols}}
-		{ {{printf "%q" .}}, reflect.TypeOf((*pkg_.{{.}})(nil)).Elem()},
+		{ {{printf "%q" .Label}}, reflect.TypeOf((*pkg_.{{.Expr}})(nil)).Elem()},
 		{{end}}
 	}
 	pkg := &model.Package{
@@ -0,0 +1,144 @@
+// Copyright 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+// This file implements -reflect_mode=gccgo: reading the export data a
+// gccgo or gollvm build embeds in its compiled archives, for projects
+// where a gc `go build` of the generated prog.go either isn't available
+// or produces artifacts gccgoimport can't read.
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/juno-lab/mock/mockgen/gccgoimport"
+	"github.com/juno-lab/mock/mockgen/model"
+)
+
+// reflectGccgo builds a model.Package for importPath by locating its
+// gccgo-compiled archive (or standalone .gox export file), parsing the
+// export data it embeds, and running it through the same
+// *types.Interface translator the packages backend uses.
+func reflectGccgo(importPath string, symbols []string) (*model.Package, error) {
+	data, err := findGccgoExportData(importPath)
+	if err != nil {
+		return nil, err
+	}
+
+	_, payload, err := gccgoimport.SplitVersion(data)
+	if err != nil {
+		return nil, err
+	}
+	tp, err := gccgoimport.Parse(payload, importPath)
+	if err != nil {
+		return nil, fmt.Errorf("parsing gccgo export data for %v: %v", importPath, err)
+	}
+
+	pkg := &model.Package{Name: tp.Name()}
+	qual := types.RelativeTo(tp)
+	for _, sym := range symbols {
+		name, _, err := parseSymbolSpec(sym)
+		if err != nil {
+			return nil, err
+		}
+		obj := tp.Scope().Lookup(name)
+		if obj == nil {
+			return nil, fmt.Errorf("no such symbol %v in package %v", name, importPath)
+		}
+		named, ok := obj.Type().(*types.Named)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a named type", name)
+		}
+		iface, ok := named.Underlying().(*types.Interface)
+		if !ok {
+			return nil, fmt.Errorf("%v is not an interface", name)
+		}
+		intf, err := model.InterfaceFromTypesInterface(name, iface, qual)
+		if err != nil {
+			return nil, fmt.Errorf("loading interface %v: %v", name, err)
+		}
+		pkg.Interfaces = append(pkg.Interfaces, intf)
+	}
+	return pkg, nil
+}
+
+// findGccgoExportData locates the export data for importPath, either as
+// a loose .gox file or as the __.PKGDEF member of a gccgo archive (.a),
+// searching GOROOT's and GOPATH's gccgo package directories the way the
+// gccgo driver itself does.
+func findGccgoExportData(importPath string) ([]byte, error) {
+	for _, dir := range gccgoPkgDirs() {
+		gox := filepath.Join(dir, importPath+".gox")
+		if b, err := os.ReadFile(gox); err == nil {
+			return b, nil
+		}
+		archive := filepath.Join(dir, importPath+".a")
+		f, err := os.Open(archive)
+		if err != nil {
+			continue
+		}
+		entries, err := gccgoimport.ReadArchive(f)
+		f.Close()
+		if err != nil {
+			continue
+		}
+		if b, err := gccgoimport.FindPKGDEF(entries); err == nil {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("no gccgo export data found for %v (looked for a .gox file or a .a archive's __.PKGDEF in %v)", importPath, gccgoPkgDirs())
+}
+
+func gccgoPkgDirs() []string {
+	suffix := fmt.Sprintf("gccgo_%s_%s", runtime.GOOS, runtime.GOARCH)
+	var dirs []string
+	if goroot := goEnv("GOROOT"); goroot != "" {
+		dirs = append(dirs, filepath.Join(goroot, "pkg", suffix))
+	}
+	for _, gp := range filepath.SplitList(goEnv("GOPATH")) {
+		if gp != "" {
+			dirs = append(dirs, filepath.Join(gp, "pkg", suffix))
+		}
+	}
+	return dirs
+}
+
+// detectGccgoToolchain reports whether the active `go` command wraps
+// gccgo or gollvm rather than the gc toolchain, so -reflect_mode can
+// default to "gccgo" without the user having to say so explicitly.
+// `go version` prints "go version gccgo ..." (or "... gollvm ...") for
+// those toolchains, versus "go version go1.x ..." for gc.
+func detectGccgoToolchain() bool {
+	out, err := exec.Command("go", "version").Output()
+	if err != nil {
+		return false
+	}
+	v := string(out)
+	return strings.Contains(v, "gccgo") || strings.Contains(v, "gollvm")
+}
+
+func goEnv(key string) string {
+	out, err := exec.Command("go", "env", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(bytes.TrimRight(out, "\n")))
+}
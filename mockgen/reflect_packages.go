@@ -0,0 +1,194 @@
+// Copyright 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+// This file contains an alternative to the exec-based Reflect that loads
+// the target package's types directly, either from source (go/packages in
+// LoadSyntax mode) or, when only compiled artifacts are available, from
+// export data (golang.org/x/tools/go/gcexportdata).
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"os"
+	"strings"
+
+	"golang.org/x/tools/go/gcexportdata"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/juno-lab/mock/mockgen/model"
+)
+
+// reflectPackages builds a model.Package for importPath by type-checking
+// it with go/packages, without generating or running any program. It is
+// the implementation behind -reflect_mode=packages. overlayPath, if
+// non-empty, is a `go build -overlay`-style JSON file substituting the
+// content of one or more source files (see overlay.go).
+func reflectPackages(importPath string, symbols []string, overlayPath string) (*model.Package, error) {
+	var overlay map[string][]byte
+	if overlayPath != "" {
+		var err error
+		overlay, err = readOverlay(overlayPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tp, err := loadTypesPackage(importPath, overlay)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &model.Package{Name: tp.Name()}
+	qual := types.RelativeTo(tp)
+
+	for _, sym := range symbols {
+		name, args, err := parseSymbolSpec(sym)
+		if err != nil {
+			return nil, err
+		}
+
+		obj := tp.Scope().Lookup(name)
+		if obj == nil {
+			return nil, fmt.Errorf("no such symbol %v in package %v", name, importPath)
+		}
+		named, ok := obj.Type().(*types.Named)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a named type", name)
+		}
+		iface, ok := named.Underlying().(*types.Interface)
+		if !ok {
+			return nil, fmt.Errorf("%v is not an interface", name)
+		}
+		var intf *model.Interface
+		if len(args) > 0 && isConcreteTypeArgs(args) {
+			instIface, err := instantiateInterface(tp, named, args)
+			if err != nil {
+				return nil, fmt.Errorf("instantiating %v[%v]: %v", name, strings.Join(args, ","), err)
+			}
+			intf, err = model.InterfaceFromTypesInterface(name, instIface, qual)
+			if err != nil {
+				return nil, fmt.Errorf("loading interface %v: %v", name, err)
+			}
+		} else {
+			intf, err = model.InterfaceFromTypesInterface(name, iface, qual)
+			if err != nil {
+				return nil, fmt.Errorf("loading interface %v: %v", name, err)
+			}
+
+			tparams, err := model.ParamsFromTypeParams(named.TypeParams(), qual)
+			if err != nil {
+				return nil, fmt.Errorf("loading type parameters of %v: %v", name, err)
+			}
+			if len(args) > 0 && len(tparams) != len(args) {
+				return nil, fmt.Errorf("%v declares %d type parameter(s), but -symbols specified %d", name, len(tparams), len(args))
+			}
+			intf.TypeParams = tparams
+		}
+
+		pkg.Interfaces = append(pkg.Interfaces, intf)
+	}
+	return pkg, nil
+}
+
+// instantiateInterface substitutes args, a "Repo[int,string]"-style
+// -symbols spec's concrete type arguments, into named's own type
+// parameters and returns the resulting instantiation's underlying
+// interface. Each arg is evaluated as a Go type expression in the scope
+// of tp, so it can name anything visible there: predeclared types,
+// types from tp itself, or types imported by tp.
+func instantiateInterface(tp *types.Package, named *types.Named, args []string) (*types.Interface, error) {
+	tparams := named.TypeParams()
+	if tparams.Len() != len(args) {
+		return nil, fmt.Errorf("%v declares %d type parameter(s), but -symbols specified %d", named.Obj().Name(), tparams.Len(), len(args))
+	}
+
+	fset := token.NewFileSet()
+	targs := make([]types.Type, len(args))
+	for i, a := range args {
+		tv, err := types.Eval(fset, tp, token.NoPos, a)
+		if err != nil {
+			return nil, fmt.Errorf("type argument %d (%q): %v", i+1, a, err)
+		}
+		targs[i] = tv.Type
+	}
+
+	inst, err := types.Instantiate(nil, named, targs, true)
+	if err != nil {
+		return nil, err
+	}
+	iface, ok := inst.Underlying().(*types.Interface)
+	if !ok {
+		return nil, fmt.Errorf("%v is not an interface", named.Obj().Name())
+	}
+	return iface, nil
+}
+
+// loadTypesPackage loads the fully type-checked *types.Package for
+// importPath, preferring syntax (so that method bodies and local types are
+// available) and falling back to export data when the package can only be
+// obtained as a compiled archive (e.g. it is provided by a vendored binary
+// dependency with no source checked out).
+func loadTypesPackage(importPath string, overlay map[string][]byte) (*types.Package, error) {
+	cfg := &packages.Config{
+		Mode:    packages.NeedName | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedSyntax | packages.NeedDeps,
+		Overlay: overlay,
+	}
+	pkgs, err := packages.Load(cfg, importPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading package %v: %v", importPath, err)
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package for %v, got %d", importPath, len(pkgs))
+	}
+	p := pkgs[0]
+	if len(p.Errors) == 0 && p.Types != nil && p.Types.Complete() {
+		return p.Types, nil
+	}
+	if len(overlay) > 0 {
+		return nil, fmt.Errorf("loading package %v with overlay: %v", importPath, p.Errors)
+	}
+
+	// Fall back to export data: ask for just the compiled archive and
+	// decode it with gcexportdata, which understands both the iexport
+	// and legacy bexport formats emitted by the gc toolchain.
+	return loadFromExportData(importPath)
+}
+
+func loadFromExportData(importPath string) (*types.Package, error) {
+	cfg := &packages.Config{Mode: packages.NeedName | packages.NeedExportFile | packages.NeedDeps | packages.NeedImports}
+	pkgs, err := packages.Load(cfg, importPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading export data for %v: %v", importPath, err)
+	}
+	if len(pkgs) != 1 || pkgs[0].ExportFile == "" {
+		return nil, fmt.Errorf("no export data available for %v; build it first", importPath)
+	}
+
+	archive, err := os.Open(pkgs[0].ExportFile)
+	if err != nil {
+		return nil, fmt.Errorf("opening export data for %v: %v", importPath, err)
+	}
+	defer archive.Close()
+
+	r, err := gcexportdata.NewReader(archive)
+	if err != nil {
+		return nil, fmt.Errorf("reading export data for %v: %v", importPath, err)
+	}
+	fset := token.NewFileSet()
+	imports := make(map[string]*types.Package)
+	return gcexportdata.Read(r, fset, imports, importPath)
+}
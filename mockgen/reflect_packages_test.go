@@ -0,0 +1,108 @@
+// Copyright 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+)
+
+func checkFixture(t *testing.T, src string) *types.Package {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "fixture.go", src, 0)
+	if err != nil {
+		t.Fatalf("parsing fixture: %v", err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("fixture", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatalf("type-checking fixture: %v", err)
+	}
+	return pkg
+}
+
+func namedInterface(t *testing.T, pkg *types.Package, name string) *types.Named {
+	t.Helper()
+	obj := pkg.Scope().Lookup(name)
+	if obj == nil {
+		t.Fatalf("%s not found in fixture", name)
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		t.Fatalf("%s is a %T, want *types.Named", name, obj.Type())
+	}
+	return named
+}
+
+func TestInstantiateInterface_ConcreteArgs(t *testing.T) {
+	pkg := checkFixture(t, `
+package fixture
+
+type Repo[K comparable, V any] interface {
+	Get(key K) (V, error)
+}
+`)
+	named := namedInterface(t, pkg, "Repo")
+
+	iface, err := instantiateInterface(pkg, named, []string{"string", "int"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if iface.NumMethods() != 1 {
+		t.Fatalf("got %d methods, want 1", iface.NumMethods())
+	}
+	sig := iface.Method(0).Type().(*types.Signature)
+	if got := sig.Params().At(0).Type().String(); got != "string" {
+		t.Fatalf("got key type %v, want string", got)
+	}
+	if got := sig.Results().At(0).Type().String(); got != "int" {
+		t.Fatalf("got value type %v, want int", got)
+	}
+}
+
+func TestInstantiateInterface_WrongArgCount(t *testing.T) {
+	pkg := checkFixture(t, `
+package fixture
+
+type Repo[T any] interface {
+	Get(id string) (T, error)
+}
+`)
+	named := namedInterface(t, pkg, "Repo")
+
+	if _, err := instantiateInterface(pkg, named, []string{"int", "string"}); err == nil {
+		t.Fatal("expected an error when -symbols supplies the wrong number of type arguments")
+	}
+}
+
+func TestInstantiateInterface_ConstraintViolation(t *testing.T) {
+	pkg := checkFixture(t, `
+package fixture
+
+type Repo[T ~int | ~string] interface {
+	Get(id string) (T, error)
+}
+`)
+	named := namedInterface(t, pkg, "Repo")
+
+	if _, err := instantiateInterface(pkg, named, []string{"bool"}); err == nil {
+		t.Fatal("expected an error when a concrete type argument doesn't satisfy the constraint")
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2012 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+// This file parses the `-symbols` syntax used to name generic interfaces,
+// e.g. "Repo[int,string]" to reflect on a concrete instantiation, or
+// "Repo[T any]" to reflect on the interface's own type parameters.
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseSymbolSpec splits a -symbols entry such as "Repo[int,string]" into
+// its base name ("Repo") and its bracketed arguments ("int", "string"),
+// trimmed of surrounding whitespace. A symbol with no brackets returns a
+// nil arg list.
+func parseSymbolSpec(sym string) (name string, args []string, err error) {
+	open := strings.IndexByte(sym, '[')
+	if open == -1 {
+		return sym, nil, nil
+	}
+	if !strings.HasSuffix(sym, "]") {
+		return "", nil, fmt.Errorf("malformed symbol %q: expected a trailing ]", sym)
+	}
+	name = strings.TrimSpace(sym[:open])
+	if name == "" {
+		return "", nil, fmt.Errorf("malformed symbol %q: missing interface name", sym)
+	}
+	for _, a := range strings.Split(sym[open+1:len(sym)-1], ",") {
+		a = strings.TrimSpace(a)
+		if a == "" {
+			return "", nil, fmt.Errorf("malformed symbol %q: empty type argument", sym)
+		}
+		args = append(args, a)
+	}
+	return name, args, nil
+}
+
+// isConcreteTypeArgs reports whether args are instantiation arguments
+// ("int", "string") rather than type parameter declarations
+// ("T any", "K comparable"), which only the packages-based backend can
+// resolve since it reads them off the interface's own declaration instead
+// of the -symbols flag.
+func isConcreteTypeArgs(args []string) bool {
+	for _, a := range args {
+		if strings.ContainsAny(a, " \t") {
+			return false
+		}
+	}
+	return true
+}